@@ -2,12 +2,22 @@ package a8n
 
 import (
 	"fmt"
+	"runtime"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/internal/a8n"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 )
 
+// parallelCalcCountsThreshold is the number of changesets above which
+// CalcCounts reconstructs each changeset's history on a worker pool instead
+// of serially, to avoid paying goroutine overhead on small inputs.
+const parallelCalcCountsThreshold = 100
+
 type ChangesetCounts struct {
 	Time                 time.Time
 	Total                int32
@@ -19,6 +29,19 @@ type ChangesetCounts struct {
 	OpenPending          int32
 }
 
+// add adds the fields of o to cc in place. Both must describe the same
+// point in time; it's used to reduce the per-changeset counts computed by
+// each worker in CalcCounts into the final, shared series.
+func (cc *ChangesetCounts) add(o *ChangesetCounts) {
+	cc.Total += o.Total
+	cc.Merged += o.Merged
+	cc.Closed += o.Closed
+	cc.Open += o.Open
+	cc.OpenApproved += o.OpenApproved
+	cc.OpenChangesRequested += o.OpenChangesRequested
+	cc.OpenPending += o.OpenPending
+}
+
 func (cc *ChangesetCounts) String() string {
 	return fmt.Sprintf("%s (Total: %d, Merged: %d, Closed: %d, Open: %d, OpenApproved: %d, OpenChangesRequested: %d, OpenPending: %d)",
 		cc.Time.String(),
@@ -48,12 +71,9 @@ func (es Events) Less(i, j int) bool {
 	return es[i].Timestamp().Before(es[j].Timestamp())
 }
 
-func CalcCounts(start, end time.Time, cs []*a8n.Changeset, es ...Event) ([]*ChangesetCounts, error) {
-	ts := generateTimestamps(start, end)
-	counts := make([]*ChangesetCounts, len(ts))
-	for i, t := range ts {
-		counts[i] = &ChangesetCounts{Time: t}
-	}
+func CalcCounts(start, end time.Time, cs []*a8n.Changeset, opts CalcCountsOpts, es ...Event) ([]*ChangesetCounts, error) {
+	ts := generateTimestamps(start, end, opts)
+	counts := newCounts(ts)
 
 	// Sort all events once by their timestamps
 	events := Events(es)
@@ -71,64 +91,419 @@ func CalcCounts(start, end time.Time, cs []*a8n.Changeset, es ...Event) ([]*Chan
 		byChangeset[c] = group
 	}
 
-	for c, csEvents := range byChangeset {
-		// We don't have an event for "open", so we check when it was
-		// created on codehost
-		openedAt, err := c.ExternalCreatedAt()
-		if err != nil {
+	if len(cs) > parallelCalcCountsThreshold {
+		if err := reduceChangesetCountsParallel(counts, ts, cs, byChangeset); err != nil {
 			return nil, err
 		}
+	} else {
+		for _, c := range cs {
+			if err := addChangesetCounts(counts, c, byChangeset[c]); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-		// For each changeset and its events, go through every point in time we
-		// want to record and reconstruct the changesets history until that
-		// point
-		for _, count := range counts {
-			t := count.Time
+	return counts, nil
+}
 
-			if openedAt.Before(t) || openedAt.Equal(t) {
-				count.Total++
-				count.Open++
-			} else {
-				// No need to look at events if changeset was not created yet
-				continue
+// reduceChangesetCountsParallel dispatches changeset reconstruction to
+// runtime.GOMAXPROCS(0) workers. Each worker accumulates into its own
+// []*ChangesetCounts slice (so the inner loop never needs to lock), and the
+// results are summed additively into counts once every worker is done.
+func reduceChangesetCountsParallel(counts []*ChangesetCounts, ts []time.Time, cs []*a8n.Changeset, byChangeset map[*a8n.Changeset]Events) error {
+	numWorkers := runtime.GOMAXPROCS(0)
+
+	// stop is closed as soon as a worker errors, so the producer below
+	// doesn't block forever trying to send the remaining jobs to workers
+	// that have already exited.
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	jobs := make(chan *a8n.Changeset)
+	go func() {
+		defer close(jobs)
+		for _, c := range cs {
+			select {
+			case jobs <- c:
+			case <-stop:
+				return
 			}
+		}
+	}()
 
-			for _, e := range csEvents {
-				// Event happened after point in time we're looking at, ignore
-				if e.Timestamp().After(t) {
-					continue
+	type workerResult struct {
+		counts []*ChangesetCounts
+		err    error
+	}
+	results := make(chan workerResult, numWorkers)
+
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+
+			local := newCounts(ts)
+			for c := range jobs {
+				if err := addChangesetCounts(local, c, byChangeset[c]); err != nil {
+					stopOnce.Do(func() { close(stop) })
+					results <- workerResult{err: err}
+					return
 				}
-				switch e.Type() {
-				case a8n.ChangesetEventKindGitHubClosed:
-					count.Open--
-					count.Closed++
-				case a8n.ChangesetEventKindGitHubReopened:
-					count.Open++
-					count.Closed--
-				case a8n.ChangesetEventKindGitHubMerged:
-					count.Merged++
-					count.Open--
+			}
+			results <- workerResult{counts: local}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if r.err != nil {
+			return r.err
+		}
+		for i, c := range r.counts {
+			counts[i].add(c)
+		}
+	}
+
+	return nil
+}
+
+// addChangesetCounts reconstructs a single changeset's history and adds it,
+// point in time by point in time, into counts. Events from any code host
+// (GitHub, GitLab, Gerrit, ...) are handled in the same pass, so a mixed set
+// of changesets produces a single, unified time-series.
+func addChangesetCounts(counts []*ChangesetCounts, c *a8n.Changeset, csEvents Events) error {
+	// We don't have an event for "open", so we check when it was
+	// created on codehost
+	openedAt, err := c.ExternalCreatedAt()
+	if err != nil {
+		return err
+	}
+
+	// For each point in time we want to record, reconstruct the changeset's
+	// history until that point.
+	for _, count := range counts {
+		t := count.Time
+
+		if openedAt.Before(t) || openedAt.Equal(t) {
+			count.Total++
+			count.Open++
+		} else {
+			// No need to look at events if changeset was not created yet
+			continue
+		}
+
+		open := true
+		var reviewState string
+		reviewers := map[int64]string{}
+
+		for _, e := range csEvents {
+			// Event happened after point in time we're looking at, ignore
+			if e.Timestamp().After(t) {
+				continue
+			}
+			switch e.Type() {
+			case a8n.ChangesetEventKindGitHubClosed, a8n.ChangesetEventKindGitLabMRClosed, a8n.ChangesetEventKindGerritChangeAbandoned:
+				count.Open--
+				count.Closed++
+				open = false
+			case a8n.ChangesetEventKindGitHubReopened, a8n.ChangesetEventKindGitLabMRReopened, a8n.ChangesetEventKindGerritChangeRestored:
+				count.Open++
+				count.Closed--
+				open = true
+			case a8n.ChangesetEventKindGitHubMerged, a8n.ChangesetEventKindGitLabMRMerged, a8n.ChangesetEventKindGerritChangeMerged:
+				count.Merged++
+				count.Open--
+				open = false
+			case a8n.ChangesetEventKindGitHubReviewed:
+				if id, state, ok := githubReview(e); ok {
+					reviewers[id] = state
 				}
+			case a8n.ChangesetEventKindGitHubReviewDismissed:
+				if id, ok := githubReviewer(e); ok {
+					delete(reviewers, id)
+				}
+			case a8n.ChangesetEventKindGitHubReviewRequestRemoved:
+				// Withdrawing a re-requested review only cancels a pending
+				// request; it doesn't invalidate a review that reviewer
+				// already submitted, so reviewers (which only ever holds
+				// submitted review states) is left untouched.
+			case a8n.ChangesetEventKindGitLabApproved:
+				reviewState = "approved"
+			case a8n.ChangesetEventKindGitLabUnapproved:
+				reviewState = "pending"
+			case a8n.ChangesetEventKindGerritCommentAdded:
+				if s, ok := codeReviewState(e); ok {
+					reviewState = s
+				}
+			}
+		}
+
+		if open {
+			if s, ok := aggregateReviewState(reviewers); ok {
+				reviewState = s
+			}
+
+			switch reviewState {
+			case "approved":
+				count.OpenApproved++
+			case "changes-requested":
+				count.OpenChangesRequested++
+			case "pending":
+				count.OpenPending++
 			}
 		}
 	}
 
-	return counts, nil
+	return nil
+}
+
+// aggregateReviewState derives a changeset's overall GitHub review state
+// from the latest state of each of its reviewers: changes are requested if
+// any current reviewer asked for them, it's approved if at least one
+// reviewer approved and none requested changes, and otherwise it's pending.
+// It reports ok=false if there are no reviewers at all, so callers can fall
+// back to another code host's simpler, single-valued review state.
+func aggregateReviewState(reviewers map[int64]string) (string, bool) {
+	if len(reviewers) == 0 {
+		return "", false
+	}
+
+	approved := false
+	for _, state := range reviewers {
+		if state == "CHANGES_REQUESTED" {
+			return "changes-requested", true
+		}
+		if state == "APPROVED" {
+			approved = true
+		}
+	}
+
+	if approved {
+		return "approved", true
+	}
+
+	return "pending", true
+}
+
+// githubReview extracts the reviewer ID and review state from a
+// ChangesetEventKindGitHubReviewed event.
+func githubReview(e Event) (int64, string, bool) {
+	ce, ok := e.(*a8n.ChangesetEvent)
+	if !ok {
+		return 0, "", false
+	}
+
+	review, ok := ce.Metadata.(*github.PullRequestReview)
+	if !ok {
+		return 0, "", false
+	}
+
+	return review.Author.ID, review.State, true
 }
 
-func generateTimestamps(start, end time.Time) []time.Time {
-	// Walk backwards from `end` to >= `start` in 1 day intervals
-	// Backwards so we always end exactly on `end`
+// githubReviewer extracts the reviewer ID a ChangesetEventKindGitHubReviewDismissed
+// event applies to.
+func githubReviewer(e Event) (int64, bool) {
+	ce, ok := e.(*a8n.ChangesetEvent)
+	if !ok {
+		return 0, false
+	}
+
+	review, ok := ce.Metadata.(*github.PullRequestReview)
+	if !ok {
+		return 0, false
+	}
+
+	return review.Author.ID, true
+}
+
+// newCounts returns a fresh []*ChangesetCounts, one per timestamp in ts,
+// all zeroed.
+func newCounts(ts []time.Time) []*ChangesetCounts {
+	counts := make([]*ChangesetCounts, len(ts))
+	for i, t := range ts {
+		counts[i] = &ChangesetCounts{Time: t}
+	}
+	return counts
+}
+
+// codeReviewState inspects a Gerrit comment-added event's Code-Review label
+// and reports the open-state it implies: a +2 is an approval, a -1 or -2
+// requests changes, and anything else (including a +1, which isn't a final
+// vote) leaves the changeset pending.
+func codeReviewState(e Event) (string, bool) {
+	ce, ok := e.(*a8n.ChangesetEvent)
+	if !ok {
+		return "", false
+	}
+
+	se, ok := ce.Metadata.(*gerrit.StreamEvent)
+	if !ok {
+		return "", false
+	}
+
+	for _, a := range se.Approvals {
+		if a.Type != "Code-Review" {
+			continue
+		}
+
+		v, err := strconv.Atoi(a.Value)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case v == 2:
+			return "approved", true
+		case v <= -1:
+			return "changes-requested", true
+		default:
+			return "pending", true
+		}
+	}
+
+	return "", false
+}
+
+// AlignMode controls where generateTimestamps' bucket boundaries fall
+// relative to start and end.
+type AlignMode int
+
+const (
+	// AlignEnd anchors the last bucket exactly on `end` and walks backwards
+	// from there in Interval steps. It's the zero value, so existing
+	// callers that don't set Align keep CalcCounts' historical behavior.
+	AlignEnd AlignMode = iota
+	// AlignStart anchors the first bucket exactly on `start`.
+	AlignStart
+	// AlignCalendar snaps the first bucket back to the calendar boundary
+	// at or before `start`: midnight for a 24h Interval, the most recent
+	// Monday midnight for a 168h (weekly) Interval.
+	AlignCalendar
+)
+
+// CalcCountsOpts configures the bucket granularity and timezone CalcCounts
+// uses when building its time-series.
+type CalcCountsOpts struct {
+	// Interval is the duration between buckets. Zero means 24h.
+	//
+	// Only 24h and 168h (weekly) get calendar-aware handling (see
+	// calendarBoundary and addInterval): a time.Duration can't represent a
+	// calendar month, which varies from 28 to 31 days, so there's no
+	// Interval value that buckets by month. Any other Interval, including
+	// one meant to approximate "a month", is stepped as a fixed duration
+	// with time.Time.Add and, under AlignCalendar, aligned to midnight
+	// rather than a calendar boundary.
+	Interval time.Duration
+	// Location is the timezone bucket boundaries are computed in, e.g. so
+	// that daily buckets line up with midnight in the customer's timezone
+	// rather than UTC. Nil means UTC.
+	Location *time.Location
+	// Align controls where the first bucket falls relative to start/end.
+	Align AlignMode
+}
+
+func (o CalcCountsOpts) withDefaults() CalcCountsOpts {
+	if o.Interval == 0 {
+		o.Interval = 24 * time.Hour
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	return o
+}
+
+func generateTimestamps(start, end time.Time, opts CalcCountsOpts) []time.Time {
+	opts = opts.withDefaults()
+
+	// Walk forward from an aligned first bucket, rather than backwards from
+	// `end`, so AlignStart and AlignCalendar have a well-defined meaning.
+	// addInterval uses time.Date arithmetic for day/week intervals instead
+	// of a fixed Add(24*time.Hour), so across a DST transition a step is
+	// 23 or 25 hours long and keeps its wall-clock time of day, instead of
+	// silently duplicating or skipping a bucket.
 	ts := []time.Time{}
-	for t := end; t.After(start) || t.Equal(start); t = t.Add(-24 * time.Hour) {
+	for t := alignedFirst(start, end, opts); !t.After(end); t = addInterval(t, opts.Interval, opts.Location) {
 		ts = append(ts, t)
 	}
 
-	// Now reverse so we go from oldest to newest in slice
-	for i := len(ts)/2 - 1; i >= 0; i-- {
-		opp := len(ts) - 1 - i
-		ts[i], ts[opp] = ts[opp], ts[i]
+	// Guarantee the series always reaches `end`, even when it doesn't fall
+	// exactly on a bucket boundary.
+	if len(ts) == 0 || ts[len(ts)-1].Before(end) {
+		ts = append(ts, end)
 	}
 
 	return ts
 }
+
+// alignedFirst picks the first bucket boundary that generateTimestamps
+// walks forward from.
+func alignedFirst(start, end time.Time, opts CalcCountsOpts) time.Time {
+	switch opts.Align {
+	case AlignStart:
+		return start
+	case AlignCalendar:
+		return calendarBoundary(start, opts)
+	default: // AlignEnd
+		t := end
+		for {
+			prev := addInterval(t, -opts.Interval, opts.Location)
+			if prev.Before(start) {
+				return t
+			}
+			t = prev
+		}
+	}
+}
+
+// calendarBoundary snaps t back to the calendar boundary at or before t in
+// opts.Location: midnight for a 24h Interval, the most recent Monday
+// midnight for a 168h Interval, and midnight otherwise. There is no calendar
+// month case: a month has no fixed Duration, so an Interval approximating
+// one (e.g. 30*24*time.Hour) only ever gets the plain-midnight fallback, not
+// alignment to the 1st of the month.
+func calendarBoundary(t time.Time, opts CalcCountsOpts) time.Time {
+	lt := t.In(opts.Location)
+	midnight := time.Date(lt.Year(), lt.Month(), lt.Day(), 0, 0, 0, 0, opts.Location)
+
+	if opts.Interval == 7*24*time.Hour {
+		// time.Monday == 1, time.Sunday == 0; this maps both onto the
+		// number of days since the most recent Monday.
+		offset := (int(lt.Weekday()) + 6) % 7
+		return midnight.AddDate(0, 0, -offset)
+	}
+
+	return midnight
+}
+
+// addInterval adds d to t. For a ±24h or ±168h (weekly) Interval it does so
+// with time.Date arithmetic in loc, which walks whole calendar days/weeks
+// instead of a fixed number of hours, so a DST transition in loc doesn't
+// shift the bucket's wall-clock time of day. Any other d, including one
+// meant to approximate a calendar month, falls back to a plain t.Add(d):
+// there is no fixed Duration that means "one calendar month", so callers
+// who need month buckets must compute their own boundaries rather than
+// relying on CalcCountsOpts.Interval.
+func addInterval(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	days := 0
+	switch d {
+	case 24 * time.Hour:
+		days = 1
+	case -24 * time.Hour:
+		days = -1
+	case 7 * 24 * time.Hour:
+		days = 7
+	case -7 * 24 * time.Hour:
+		days = -7
+	default:
+		return t.Add(d)
+	}
+
+	lt := t.In(loc)
+	return time.Date(lt.Year(), lt.Month(), lt.Day()+days, lt.Hour(), lt.Minute(), lt.Second(), lt.Nanosecond(), loc)
+}
@@ -14,7 +14,7 @@ import (
 var update = flag.Bool("update", false, "update testdata")
 
 func TestCalcCounts(t *testing.T) {
-	now := time.Now().Truncate(time.Microsecond)
+	now := time.Now().UTC().Truncate(time.Microsecond)
 	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
 
 	ghChangesetCreated := func(id int64, t time.Time) *a8n.Changeset {
@@ -252,6 +252,135 @@ func TestCalcCounts(t *testing.T) {
 				{Time: daysAgo(0), Total: 1, Merged: 1},
 			},
 		},
+		{
+			name: "multiple reviewers approve, dismiss, re-request, approve",
+			changesets: []*a8n.Changeset{
+				ghChangesetCreated(1, daysAgo(6)),
+			},
+			start: daysAgo(6),
+			events: []Event{
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(5),
+						State:     "APPROVED",
+						Author:    github.Actor{ID: 1},
+					},
+				},
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewDismissed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(4),
+						State:     "DISMISSED",
+						Author:    github.Actor{ID: 1},
+					},
+				},
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(3),
+						State:     "APPROVED",
+						Author:    github.Actor{ID: 1},
+					},
+				},
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(2),
+						State:     "CHANGES_REQUESTED",
+						Author:    github.Actor{ID: 2},
+					},
+				},
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewDismissed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(1),
+						State:     "DISMISSED",
+						Author:    github.Actor{ID: 2},
+					},
+				},
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(6), Total: 1, Open: 1},
+				{Time: daysAgo(5), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(4), Total: 1, Open: 1},
+				{Time: daysAgo(3), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(2), Total: 1, Open: 1, OpenChangesRequested: 1},
+				{Time: daysAgo(1), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(0), Total: 1, Open: 1, OpenApproved: 1},
+			},
+		},
+		{
+			name: "review request removed before reviewer ever reviewed doesn't affect other reviewers",
+			changesets: []*a8n.Changeset{
+				ghChangesetCreated(1, daysAgo(2)),
+			},
+			start: daysAgo(2),
+			events: []Event{
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(1),
+						State:     "APPROVED",
+						Author:    github.Actor{ID: 1},
+					},
+				},
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewRequestRemoved,
+					Metadata: &github.ReviewRequestRemoved{
+						Reviewer:  github.Actor{ID: 2},
+						RemovedAt: daysAgo(1),
+					},
+				},
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(2), Total: 1, Open: 1},
+				{Time: daysAgo(1), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(0), Total: 1, Open: 1, OpenApproved: 1},
+			},
+		},
+		{
+			name: "review request removed after reviewer already approved doesn't erase their review",
+			changesets: []*a8n.Changeset{
+				ghChangesetCreated(1, daysAgo(3)),
+			},
+			start: daysAgo(3),
+			events: []Event{
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewed,
+					Metadata: &github.PullRequestReview{
+						UpdatedAt: daysAgo(2),
+						State:     "APPROVED",
+						Author:    github.Actor{ID: 1},
+					},
+				},
+				// The author re-requests a look from the same reviewer...
+				// ...then withdraws the request before they act on it. Their
+				// earlier APPROVED review is still in effect.
+				&a8n.ChangesetEvent{
+					ChangesetID: 1,
+					Kind:        a8n.ChangesetEventKindGitHubReviewRequestRemoved,
+					Metadata: &github.ReviewRequestRemoved{
+						Reviewer:  github.Actor{ID: 1},
+						RemovedAt: daysAgo(1),
+					},
+				},
+			},
+			want: []*ChangesetCounts{
+				{Time: daysAgo(3), Total: 1, Open: 1},
+				{Time: daysAgo(2), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(1), Total: 1, Open: 1, OpenApproved: 1},
+				{Time: daysAgo(0), Total: 1, Open: 1, OpenApproved: 1},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -260,7 +389,7 @@ func TestCalcCounts(t *testing.T) {
 				tc.end = now
 			}
 
-			have, err := CalcCounts(tc.start, tc.end, tc.changesets, tc.events...)
+			have, err := CalcCounts(tc.start, tc.end, tc.changesets, CalcCountsOpts{}, tc.events...)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -272,6 +401,124 @@ func TestCalcCounts(t *testing.T) {
 	}
 }
 
+func TestGenerateTimestamps(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		opts  CalcCountsOpts
+		want  []string
+	}{
+		{
+			name:  "default daily, aligned to end",
+			start: "2019-01-01T15:00:00Z",
+			end:   "2019-01-03T15:00:00Z",
+			opts:  CalcCountsOpts{},
+			want: []string{
+				"2019-01-01T15:00:00Z",
+				"2019-01-02T15:00:00Z",
+				"2019-01-03T15:00:00Z",
+			},
+		},
+		{
+			name:  "hourly interval",
+			start: "2019-01-01T10:00:00Z",
+			end:   "2019-01-01T13:00:00Z",
+			opts:  CalcCountsOpts{Interval: time.Hour},
+			want: []string{
+				"2019-01-01T10:00:00Z",
+				"2019-01-01T11:00:00Z",
+				"2019-01-01T12:00:00Z",
+				"2019-01-01T13:00:00Z",
+			},
+		},
+		{
+			name:  "align start",
+			start: "2019-01-01T15:30:00Z",
+			end:   "2019-01-03T10:00:00Z",
+			opts:  CalcCountsOpts{Align: AlignStart},
+			want: []string{
+				"2019-01-01T15:30:00Z",
+				"2019-01-02T15:30:00Z",
+				"2019-01-03T10:00:00Z",
+			},
+		},
+		{
+			name:  "align calendar snaps to midnight in Location",
+			start: "2019-01-01T15:30:00Z", // 10:30 EST
+			end:   "2019-01-02T15:30:00Z",
+			opts:  CalcCountsOpts{Align: AlignCalendar, Location: newYork},
+			want: []string{
+				"2019-01-01T05:00:00Z", // 2019-01-01T00:00:00-05:00
+				"2019-01-02T05:00:00Z",
+				"2019-01-02T15:30:00Z",
+			},
+		},
+		{
+			name:  "daily bucket across a DST transition doesn't duplicate or skip a day",
+			start: "2019-03-09T05:00:00Z", // 2019-03-09T00:00:00-05:00 (EST)
+			end:   "2019-03-11T04:00:00Z", // 2019-03-11T00:00:00-04:00 (EDT, after spring-forward)
+			opts:  CalcCountsOpts{Align: AlignStart, Location: newYork},
+			want: []string{
+				"2019-03-09T05:00:00Z",
+				"2019-03-10T05:00:00Z", // local midnight, still EST (-05:00); DST starts at 02:00 that day
+				"2019-03-11T04:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start := parse(t, tc.start)
+			end := parse(t, tc.end)
+
+			have := generateTimestamps(start, end, tc.opts)
+
+			want := make([]time.Time, len(tc.want))
+			for i, s := range tc.want {
+				want[i] = parse(t, s)
+			}
+
+			if len(have) != len(want) {
+				t.Fatalf("have %d timestamps, want %d\nhave=%v\nwant=%v", len(have), len(want), have, want)
+			}
+
+			for i := range have {
+				if !have[i].Equal(want[i]) {
+					t.Errorf("timestamp %d: have %s, want %s", i, have[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestCalcCountsParallelPathReturnsOnError guards against a deadlock in
+// reduceChangesetCountsParallel: if every worker errors out before the
+// producer goroutine finishes sending jobs, the producer used to block
+// forever on an unbuffered send with no receiver left. This only shows up
+// above parallelCalcCountsThreshold, where CalcCounts takes the parallel
+// path; a hung producer would make this test time out.
+func TestCalcCountsParallelPathReturnsOnError(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	cs := make([]*a8n.Changeset, parallelCalcCountsThreshold+1)
+	for i := range cs {
+		// Metadata of an unrecognized type makes every changeset fail in
+		// ExternalCreatedAt, so every worker errors out on its first job.
+		cs[i] = &a8n.Changeset{ID: int64(i + 1), Metadata: "not a real changeset"}
+	}
+
+	_, err := CalcCounts(now.AddDate(0, 0, -2), now, cs, CalcCountsOpts{})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
 func parse(t testing.TB, ts string) time.Time {
 	t.Helper()
 
@@ -0,0 +1,118 @@
+package a8n
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/sourcegraph/sourcegraph/internal/a8n"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+)
+
+// GerritSource feeds the same Changeset/ChangesetEvent pipeline CalcCounts
+// consumes from a Gerrit instance's SSH stream-events feed, mirroring what
+// GitHubWebhook and GitLabWebhook do for their respective code hosts.
+type GerritSource struct {
+	Store *Store
+	REST  *gerrit.Client
+	Watch *gerrit.StreamWatcher
+}
+
+// Run bootstraps open changes from Gerrit's REST API and then consumes the
+// stream-events feed until ctx is canceled, upserting a Changeset or
+// ChangesetEvent for everything it sees.
+func (s *GerritSource) Run(ctx context.Context) error {
+	if err := s.bootstrap(ctx); err != nil {
+		return err
+	}
+
+	for ev := range s.Watch.Watch(ctx) {
+		if err := s.handle(ctx, ev); err != nil {
+			log.Printf("gerrit: failed to handle %s event: %s", ev.Type, err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// bootstrap lists the changes that are currently open and creates a
+// Changeset for any this instance doesn't already know about, so the counts
+// series has correct Total/Open values before any stream events arrive.
+// Run calls bootstrap every time it (re)starts, so it must be safe to call
+// repeatedly: it skips changes that a previous bootstrap already stored,
+// instead of trying to create them again.
+func (s *GerritSource) bootstrap(ctx context.Context) error {
+	changes, err := s.REST.ListOpenChanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	var cs []*a8n.Changeset
+	for _, c := range changes {
+		known, err := s.knownChangeset(ctx, c.Number)
+		if err != nil {
+			return err
+		}
+		if known {
+			continue
+		}
+
+		cs = append(cs, &a8n.Changeset{ID: c.Number, Metadata: c})
+	}
+
+	if len(cs) == 0 {
+		return nil
+	}
+
+	return s.Store.CreateChangesets(ctx, cs...)
+}
+
+// knownChangeset reports whether a Changeset for the Gerrit change numbered
+// number has already been stored.
+func (s *GerritSource) knownChangeset(ctx context.Context, number int64) (bool, error) {
+	cs, _, err := s.Store.ListChangesets(ctx, ListChangesetsOpts{ExternalID: strconv.FormatInt(number, 10), Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	return len(cs) > 0, nil
+}
+
+// handle translates a single stream-events line into a ChangesetEvent and
+// upserts it, or does nothing for event types CalcCounts doesn't need.
+func (s *GerritSource) handle(ctx context.Context, ev gerrit.StreamEvent) error {
+	kind, ok := gerritEventKind(ev)
+	if !ok {
+		return nil
+	}
+
+	e := ev
+	return s.Store.UpsertChangesetEvents(ctx, &a8n.ChangesetEvent{
+		ChangesetID: ev.Change.Number,
+		Kind:        kind,
+		Metadata:    &e,
+	})
+}
+
+// gerritEventKind maps a Gerrit stream-events type to the ChangesetEventKind
+// CalcCounts understands. It reports ok=false for event types CalcCounts
+// doesn't need, or that aren't tied to a change.
+func gerritEventKind(ev gerrit.StreamEvent) (a8n.ChangesetEventKind, bool) {
+	if ev.Change == nil {
+		return "", false
+	}
+
+	switch ev.Type {
+	case gerrit.StreamEventPatchsetCreated:
+		return a8n.ChangesetEventKindGerritPatchsetCreated, true
+	case gerrit.StreamEventChangeMerged:
+		return a8n.ChangesetEventKindGerritChangeMerged, true
+	case gerrit.StreamEventChangeAbandoned:
+		return a8n.ChangesetEventKindGerritChangeAbandoned, true
+	case gerrit.StreamEventChangeRestored:
+		return a8n.ChangesetEventKindGerritChangeRestored, true
+	case gerrit.StreamEventCommentAdded:
+		return a8n.ChangesetEventKindGerritCommentAdded, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,46 @@
+package a8n
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/a8n"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+)
+
+func TestGerritEventKind(t *testing.T) {
+	change := &gerrit.Change{Number: 1}
+
+	tests := []struct {
+		name string
+		ev   gerrit.StreamEvent
+		want a8n.ChangesetEventKind // zero value means ok should be false
+	}{
+		{name: "patchset created", ev: gerrit.StreamEvent{Type: gerrit.StreamEventPatchsetCreated, Change: change}, want: a8n.ChangesetEventKindGerritPatchsetCreated},
+		{name: "change merged", ev: gerrit.StreamEvent{Type: gerrit.StreamEventChangeMerged, Change: change}, want: a8n.ChangesetEventKindGerritChangeMerged},
+		{name: "change abandoned", ev: gerrit.StreamEvent{Type: gerrit.StreamEventChangeAbandoned, Change: change}, want: a8n.ChangesetEventKindGerritChangeAbandoned},
+		{name: "change restored", ev: gerrit.StreamEvent{Type: gerrit.StreamEventChangeRestored, Change: change}, want: a8n.ChangesetEventKindGerritChangeRestored},
+		{name: "comment added", ev: gerrit.StreamEvent{Type: gerrit.StreamEventCommentAdded, Change: change}, want: a8n.ChangesetEventKindGerritCommentAdded},
+		{name: "unrecognized type", ev: gerrit.StreamEvent{Type: "reviewer-added", Change: change}},
+		{name: "no change attached", ev: gerrit.StreamEvent{Type: gerrit.StreamEventPatchsetCreated}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, ok := gerritEventKind(tc.ev)
+
+			if tc.want == "" {
+				if ok {
+					t.Fatalf("expected ok=false, got kind %q", kind)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatal("expected ok=true, got false")
+			}
+			if kind != tc.want {
+				t.Errorf("have kind %q, want %q", kind, tc.want)
+			}
+		})
+	}
+}
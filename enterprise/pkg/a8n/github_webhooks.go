@@ -0,0 +1,80 @@
+package a8n
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+)
+
+// ServeGitHubDeliveries returns an admin-only HTTP handler that lists recent
+// webhook deliveries for a repository's webhook on client, or redeliver one
+// by its ID, so operators can recover dropped changeset events after an
+// outage without re-syncing every PR from scratch.
+//
+// It expects "owner", "repo" and "hook_id" query parameters, and an optional
+// "redeliver" parameter naming the delivery to redeliver.
+func ServeGitHubDeliveries(client *github.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := parseDeliveriesRequest(r)
+		if err != nil {
+			respond(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		if req.hasRedeliver {
+			if err := client.RedeliverWebhookDelivery(ctx, req.owner, req.repo, req.hookID, req.redeliverID); err != nil {
+				respond(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			respond(w, http.StatusOK, nil)
+			return
+		}
+
+		deliveries, err := client.ListWebhookDeliveries(ctx, req.owner, req.repo, req.hookID)
+		if err != nil {
+			respond(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		respond(w, http.StatusOK, deliveries)
+	}
+}
+
+// deliveriesRequest is the parsed form of the query parameters
+// ServeGitHubDeliveries accepts.
+type deliveriesRequest struct {
+	owner, repo  string
+	hookID       int
+	redeliverID  int64
+	hasRedeliver bool
+}
+
+// parseDeliveriesRequest extracts the "owner", "repo", "hook_id" and
+// optional "redeliver" query parameters from r.
+func parseDeliveriesRequest(r *http.Request) (deliveriesRequest, error) {
+	q := r.URL.Query()
+
+	req := deliveriesRequest{owner: q.Get("owner"), repo: q.Get("repo")}
+
+	hookID, err := strconv.Atoi(q.Get("hook_id"))
+	if err != nil {
+		return deliveriesRequest{}, errors.Wrap(err, "invalid hook_id")
+	}
+	req.hookID = hookID
+
+	if redeliver := q.Get("redeliver"); redeliver != "" {
+		redeliverID, err := strconv.ParseInt(redeliver, 10, 64)
+		if err != nil {
+			return deliveriesRequest{}, errors.Wrap(err, "invalid redeliver")
+		}
+		req.redeliverID = redeliverID
+		req.hasRedeliver = true
+	}
+
+	return req, nil
+}
@@ -0,0 +1,66 @@
+package a8n
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseDeliveriesRequest(t *testing.T) {
+	newRequest := func(query string) *http.Request {
+		return &http.Request{URL: &url.URL{RawQuery: query}}
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    deliveriesRequest
+		wantErr bool
+	}{
+		{
+			name:  "list, no redeliver",
+			query: "owner=acme&repo=widgets&hook_id=1",
+			want:  deliveriesRequest{owner: "acme", repo: "widgets", hookID: 1},
+		},
+		{
+			name:  "redeliver",
+			query: "owner=acme&repo=widgets&hook_id=1&redeliver=42",
+			want:  deliveriesRequest{owner: "acme", repo: "widgets", hookID: 1, redeliverID: 42, hasRedeliver: true},
+		},
+		{
+			name:    "missing hook_id",
+			query:   "owner=acme&repo=widgets",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric hook_id",
+			query:   "owner=acme&repo=widgets&hook_id=abc",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric redeliver",
+			query:   "owner=acme&repo=widgets&hook_id=1&redeliver=abc",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			have, err := parseDeliveriesRequest(newRequest(tc.query))
+
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != tc.want {
+				t.Errorf("have %+v, want %+v", have, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package a8n
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/a8n"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// GitLabWebhook receives GitLab merge request and note webhooks and creates
+// ChangesetEvents for the changesets they relate to, mirroring GitHubWebhook.
+type GitLabWebhook struct {
+	Store *Store
+	Repos *repos.DBStore
+	Now   func() time.Time
+}
+
+// gitlabEvent is the subset of the GitLab webhook payload that is common to
+// every event type GitLab sends: https://docs.gitlab.com/ee/user/project/integrations/webhooks.html
+//
+// Which of ObjectAttributes' fields are populated depends on ObjectKind: a
+// "merge_request" event's ObjectAttributes is the merge request itself (IID,
+// Action), while a "note" event's ObjectAttributes is the note (Note,
+// System) and the merge request it was left on is instead found under the
+// top-level MergeRequest field.
+type gitlabEvent struct {
+	ObjectKind string `json:"object_kind"`
+	EventType  string `json:"event_type"`
+	ProjectID  int64  `json:"project_id"`
+
+	ObjectAttributes struct {
+		IID    int64  `json:"iid"`
+		Action string `json:"action"`
+		Note   string `json:"note"`
+		System bool   `json:"system"`
+	} `json:"object_attributes"`
+
+	// MergeRequest identifies the merge request a "note" event's note was
+	// left on. It is absent on "merge_request" events, which carry the
+	// merge request's own IID under ObjectAttributes instead.
+	MergeRequest *gitlab.MergeRequest `json:"merge_request"`
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *GitLabWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	extSvc, err := h.authenticate(r)
+	if err != nil {
+		respond(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var event gitlabEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		respond(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if event.ObjectKind != "merge_request" && event.ObjectKind != "note" {
+		respond(w, http.StatusOK, nil)
+		return
+	}
+
+	changeset, err := h.loadChangeset(ctx, extSvc, event)
+	if err != nil {
+		respond(w, http.StatusOK, nil)
+		return
+	}
+
+	ev := h.convertEvent(event, changeset.ID)
+	if ev == nil {
+		respond(w, http.StatusOK, nil)
+		return
+	}
+
+	if err := h.Store.UpsertChangesetEvents(ctx, ev); err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(w, http.StatusOK, nil)
+}
+
+// authenticate verifies the X-Gitlab-Token header against the secret
+// configured for one of the GitLab external services this instance knows
+// about.
+func (h *GitLabWebhook) authenticate(r *http.Request) (*repos.ExternalService, error) {
+	token := r.Header.Get("X-Gitlab-Token")
+	if token == "" {
+		return nil, errors.New("missing X-Gitlab-Token header")
+	}
+
+	es, err := h.Repos.ListExternalServices(r.Context(), repos.StoreListExternalServicesArgs{Kinds: []string{"GITLAB"}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range es {
+		c, err := e.Configuration()
+		if err != nil {
+			continue
+		}
+
+		conn, ok := c.(*schema.GitLabConnection)
+		if !ok {
+			continue
+		}
+
+		secrets := make([]string, 0, len(conn.Webhooks))
+		for _, wh := range conn.Webhooks {
+			secrets = append(secrets, wh.Secret)
+		}
+
+		if matchesSecret(secrets, token) {
+			return e, nil
+		}
+	}
+
+	return nil, errors.New("no matching webhook secret found")
+}
+
+// matchesSecret reports whether token matches any of secrets, using a
+// constant-time comparison so a timing attack can't be used to guess a
+// webhook secret one byte at a time. An empty secret never matches.
+func matchesSecret(secrets []string, token string) bool {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadChangeset finds the Changeset the event's merge request belongs to.
+func (h *GitLabWebhook) loadChangeset(ctx context.Context, extSvc *repos.ExternalService, event gitlabEvent) (*a8n.Changeset, error) {
+	cs, _, err := h.Store.ListChangesets(ctx, ListChangesetsOpts{ExternalID: mrExternalID(event), Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cs) == 0 {
+		return nil, errors.New("changeset not found")
+	}
+
+	return cs[0], nil
+}
+
+// convertEvent translates a GitLab webhook payload into a ChangesetEvent, or
+// returns nil if the event doesn't map to one CalcCounts understands.
+func (h *GitLabWebhook) convertEvent(event gitlabEvent, changesetID int64) *a8n.ChangesetEvent {
+	now := h.Now()
+
+	switch event.ObjectKind {
+	case "merge_request":
+		var kind a8n.ChangesetEventKind
+		switch event.ObjectAttributes.Action {
+		case "close":
+			kind = a8n.ChangesetEventKindGitLabMRClosed
+		case "reopen":
+			kind = a8n.ChangesetEventKindGitLabMRReopened
+		case "merge":
+			kind = a8n.ChangesetEventKindGitLabMRMerged
+		default:
+			return nil
+		}
+
+		mr := &gitlab.MergeRequest{IID: event.ObjectAttributes.IID, UpdatedAt: now}
+		return &a8n.ChangesetEvent{ChangesetID: changesetID, Kind: kind, Metadata: mr}
+
+	case "note":
+		// GitLab doesn't give approvals and their withdrawal their own
+		// object_kind: they arrive as system notes, distinguishable only by
+		// their free-text body. Check for "unapproved" first, since its
+		// body also contains the substring "approved".
+		if !event.ObjectAttributes.System {
+			return nil
+		}
+
+		note := &gitlab.Note{Body: event.ObjectAttributes.Note, System: true, CreatedAt: now}
+		switch {
+		case strings.Contains(note.Body, "unapproved"):
+			return &a8n.ChangesetEvent{ChangesetID: changesetID, Kind: a8n.ChangesetEventKindGitLabUnapproved, Metadata: note}
+		case strings.Contains(note.Body, "approved"):
+			return &a8n.ChangesetEvent{ChangesetID: changesetID, Kind: a8n.ChangesetEventKindGitLabApproved, Metadata: note}
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// mrExternalID returns the IID of the merge request event relates to: its
+// own IID for a "merge_request" event, or the IID of the merge request it
+// was left on for a "note" event.
+func mrExternalID(event gitlabEvent) string {
+	if event.MergeRequest != nil {
+		return strconv.FormatInt(event.MergeRequest.IID, 10)
+	}
+	return strconv.FormatInt(event.ObjectAttributes.IID, 10)
+}
+
+// respond writes v as the HTTP response body with the given status code: an
+// error's message as plain text, or anything else JSON-encoded.
+func respond(w http.ResponseWriter, code int, v interface{}) {
+	w.WriteHeader(code)
+
+	switch val := v.(type) {
+	case nil:
+	case error:
+		fmt.Fprintf(w, "%v", val)
+	default:
+		json.NewEncoder(w).Encode(val)
+	}
+}
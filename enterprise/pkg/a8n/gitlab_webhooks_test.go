@@ -0,0 +1,183 @@
+package a8n
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/a8n"
+)
+
+func TestMatchesSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		token   string
+		want    bool
+	}{
+		{name: "match", secrets: []string{"wrong", "right"}, token: "right", want: true},
+		{name: "no match", secrets: []string{"wrong", "also-wrong"}, token: "right", want: false},
+		{name: "no secrets", secrets: nil, token: "right", want: false},
+		{name: "empty token never matches empty secret", secrets: []string{""}, token: "", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			have := matchesSecret(tc.secrets, tc.token)
+			if have != tc.want {
+				t.Errorf("have %v, want %v", have, tc.want)
+			}
+		})
+	}
+}
+
+// These fixtures are trimmed down versions of the real payloads GitLab's
+// Merge Request Hook and Note Hook send:
+// https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#merge-request-events
+// https://docs.gitlab.com/ee/user/project/integrations/webhooks.html#comment-events
+const (
+	mergeRequestEventJSON = `{
+		"object_kind": "merge_request",
+		"event_type": "merge_request",
+		"project_id": 99,
+		"object_attributes": {
+			"iid": 1,
+			"action": %q
+		}
+	}`
+
+	noteEventJSON = `{
+		"object_kind": "note",
+		"event_type": "note",
+		"project_id": 99,
+		"object_attributes": {
+			"note": %q,
+			"system": %v
+		},
+		"merge_request": {
+			"iid": 1
+		}
+	}`
+)
+
+func decodeEvent(t *testing.T, raw string) gitlabEvent {
+	t.Helper()
+
+	var event gitlabEvent
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestGitLabWebhookConvertEvent(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	h := &GitLabWebhook{Now: func() time.Time { return now }}
+
+	tests := []struct {
+		name string
+		json string
+		want a8n.ChangesetEventKind // zero value means convertEvent should return nil
+	}{
+		{
+			name: "merge request closed",
+			json: fmt.Sprintf(mergeRequestEventJSON, "close"),
+			want: a8n.ChangesetEventKindGitLabMRClosed,
+		},
+		{
+			name: "merge request reopened",
+			json: fmt.Sprintf(mergeRequestEventJSON, "reopen"),
+			want: a8n.ChangesetEventKindGitLabMRReopened,
+		},
+		{
+			name: "merge request merged",
+			json: fmt.Sprintf(mergeRequestEventJSON, "merge"),
+			want: a8n.ChangesetEventKindGitLabMRMerged,
+		},
+		{
+			name: "merge request unrecognized action",
+			json: fmt.Sprintf(mergeRequestEventJSON, "update"),
+		},
+		{
+			name: "note approved",
+			json: fmt.Sprintf(noteEventJSON, "approved this merge request", true),
+			want: a8n.ChangesetEventKindGitLabApproved,
+		},
+		{
+			name: "note unapproved",
+			json: fmt.Sprintf(noteEventJSON, "unapproved this merge request", true),
+			want: a8n.ChangesetEventKindGitLabUnapproved,
+		},
+		{
+			name: "note that happens to contain approved but isn't a system note",
+			json: fmt.Sprintf(noteEventJSON, "LGTM, approved!", false),
+		},
+		{
+			name: "note unrelated to approval",
+			json: fmt.Sprintf(noteEventJSON, "changed the description", true),
+		},
+		{
+			name: "unrecognized object kind",
+			json: `{"object_kind": "pipeline"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := decodeEvent(t, tc.json)
+			ev := h.convertEvent(event, 42)
+
+			if tc.want == "" {
+				if ev != nil {
+					t.Fatalf("expected nil event, got %+v", ev)
+				}
+				return
+			}
+
+			if ev == nil {
+				t.Fatal("expected event, got nil")
+			}
+			if ev.ChangesetID != 42 {
+				t.Errorf("have ChangesetID %d, want 42", ev.ChangesetID)
+			}
+			if ev.Kind != tc.want {
+				t.Errorf("have Kind %q, want %q", ev.Kind, tc.want)
+			}
+
+			// Metadata must be a concrete, non-nil type that Timestamp can
+			// handle, or sorting changeset events by timestamp will panic.
+			if ev.Timestamp().IsZero() {
+				t.Errorf("event Timestamp is zero")
+			}
+		})
+	}
+}
+
+func TestMRExternalID(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "merge request event uses its own iid",
+			json: fmt.Sprintf(mergeRequestEventJSON, "close"),
+			want: "1",
+		},
+		{
+			name: "note event uses its merge request's iid",
+			json: fmt.Sprintf(noteEventJSON, "approved this merge request", true),
+			want: "1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := decodeEvent(t, tc.json)
+			if have := mrExternalID(event); have != tc.want {
+				t.Errorf("have %q, want %q", have, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package a8n contains code shared between different parts of the
+// automation ("a8n") feature, most notably the types used to represent
+// changesets and the events that happened on them, regardless of which code
+// host they came from.
+package a8n
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+)
+
+// ChangesetEventKind defines the kind of a ChangesetEvent. Its valid values
+// are the constants defined below.
+type ChangesetEventKind string
+
+// Valid ChangesetEvent kinds
+const (
+	ChangesetEventKindGitHubReviewed             ChangesetEventKind = "githubreviewed"
+	ChangesetEventKindGitHubReviewDismissed      ChangesetEventKind = "githubreviewdismissed"
+	ChangesetEventKindGitHubReviewRequestRemoved ChangesetEventKind = "githubreviewrequestremoved"
+	ChangesetEventKindGitHubClosed               ChangesetEventKind = "githubclosed"
+	ChangesetEventKindGitHubReopened             ChangesetEventKind = "githubreopened"
+	ChangesetEventKindGitHubMerged               ChangesetEventKind = "githubmerged"
+
+	ChangesetEventKindGitLabMRClosed   ChangesetEventKind = "gitlabmrclosed"
+	ChangesetEventKindGitLabMRReopened ChangesetEventKind = "gitlabmrreopened"
+	ChangesetEventKindGitLabMRMerged   ChangesetEventKind = "gitlabmrmerged"
+	ChangesetEventKindGitLabApproved   ChangesetEventKind = "gitlabapproved"
+	ChangesetEventKindGitLabUnapproved ChangesetEventKind = "gitlabunapproved"
+
+	ChangesetEventKindGerritPatchsetCreated ChangesetEventKind = "gerritpatchsetcreated"
+	ChangesetEventKindGerritChangeMerged    ChangesetEventKind = "gerritchangemerged"
+	ChangesetEventKindGerritChangeAbandoned ChangesetEventKind = "gerritchangeabandoned"
+	ChangesetEventKindGerritChangeRestored  ChangesetEventKind = "gerritchangerestored"
+	ChangesetEventKindGerritCommentAdded    ChangesetEventKind = "gerritcommentadded"
+)
+
+// Changeset is a changeset on a code host, e.g. a GitHub pull request or a
+// GitLab merge request.
+type Changeset struct {
+	ID       int64
+	Metadata interface{}
+}
+
+// ExternalCreatedAt returns the time the changeset was created on the code
+// host it is hosted on, based on the concrete type of Metadata.
+func (c *Changeset) ExternalCreatedAt() (time.Time, error) {
+	switch m := c.Metadata.(type) {
+	case *github.PullRequest:
+		return m.CreatedAt, nil
+	case *gitlab.MergeRequest:
+		return m.CreatedAt, nil
+	case *gerrit.Change:
+		return m.CreatedAt()
+	default:
+		return time.Time{}, errors.Errorf("changeset has unknown metadata type %T", c.Metadata)
+	}
+}
+
+// ChangesetEvent is an event that happened on a changeset on a code host,
+// e.g. a review being submitted or a pull request being merged.
+type ChangesetEvent struct {
+	ChangesetID int64
+	Kind        ChangesetEventKind
+	Metadata    interface{}
+}
+
+// Timestamp returns the time at which the event happened on the code host,
+// based on the concrete type of Metadata.
+func (e *ChangesetEvent) Timestamp() time.Time {
+	switch m := e.Metadata.(type) {
+	case *github.PullRequestReview:
+		return m.UpdatedAt
+	case *github.PullRequest:
+		return m.UpdatedAt
+	case *gitlab.Note:
+		return m.CreatedAt
+	case *gitlab.MergeRequest:
+		return m.UpdatedAt
+	case *gerrit.StreamEvent:
+		return m.Timestamp()
+	case *github.ReviewRequestRemoved:
+		return m.RemovedAt
+	default:
+		return time.Time{}
+	}
+}
+
+// Type returns the kind of the event.
+func (e *ChangesetEvent) Type() ChangesetEventKind { return e.Kind }
+
+// Changeset returns the ID of the changeset the event belongs to.
+func (e *ChangesetEvent) Changeset() int64 { return e.ChangesetID }
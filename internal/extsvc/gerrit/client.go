@@ -0,0 +1,69 @@
+package gerrit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// xssiPrefix is prepended by Gerrit to every JSON response from its REST API
+// to guard against cross-site script inclusion. It must be stripped before
+// the remainder of the line can be decoded as JSON.
+const xssiPrefix = ")]}'"
+
+// Client talks to a Gerrit instance's REST API.
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client configured to talk to the Gerrit instance at
+// the given base URL, e.g. "https://gerrit.example.com".
+func NewClient(url string) *Client {
+	return &Client{URL: strings.TrimSuffix(url, "/"), HTTPClient: http.DefaultClient}
+}
+
+// ListOpenChanges lists the currently open changes across all projects,
+// used to backfill state on startup before stream events start arriving.
+func (c *Client) ListOpenChanges(ctx context.Context) ([]*Change, error) {
+	req, err := http.NewRequest("GET", c.URL+"/changes/?q=status:open", nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gerrit: unexpected status %d listing open changes", resp.StatusCode)
+	}
+
+	var changes []*Change
+	if err := decodeXSSI(resp.Body, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// decodeXSSI strips Gerrit's ")]}'" XSSI-protection prefix line, if present,
+// before JSON-decoding the rest of the body.
+func decodeXSSI(r interface{ Read([]byte) (int, error) }, v interface{}) error {
+	br := bufio.NewReader(r)
+
+	peek, err := br.Peek(len(xssiPrefix))
+	if err == nil && string(peek) == xssiPrefix {
+		if _, err := br.Discard(len(xssiPrefix)); err != nil {
+			return err
+		}
+	}
+
+	return json.NewDecoder(br).Decode(v)
+}
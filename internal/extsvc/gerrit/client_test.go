@@ -0,0 +1,48 @@
+package gerrit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeXSSI(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Change
+	}{
+		{
+			name: "with XSSI prefix",
+			body: ")]}'\n[{\"_number\":1},{\"_number\":2}]",
+			want: []Change{{Number: 1}, {Number: 2}},
+		},
+		{
+			name: "without XSSI prefix",
+			body: "[{\"_number\":1}]",
+			want: []Change{{Number: 1}},
+		},
+		{
+			name: "empty list",
+			body: "[]",
+			want: []Change{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var have []Change
+			if err := decodeXSSI(strings.NewReader(tc.body), &have); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(have) != len(tc.want) {
+				t.Fatalf("have %d changes, want %d", len(have), len(tc.want))
+			}
+			for i := range have {
+				if have[i].Number != tc.want[i].Number {
+					t.Errorf("change %d: have Number %d, want %d", i, have[i].Number, tc.want[i].Number)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Package gerrit contains the types and helpers needed to talk to a Gerrit
+// instance, both over its REST API and over the SSH "stream-events" feed.
+package gerrit
+
+import "time"
+
+// TimeLayout is the layout Gerrit uses for timestamps in its REST API
+// responses, e.g. "2019-01-02 15:04:05.000000000".
+const TimeLayout = "2006-01-02 15:04:05.000000000"
+
+// ParseTime parses a Gerrit-formatted timestamp.
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(TimeLayout, s)
+}
+
+// Change is a Gerrit change, the Gerrit equivalent of a GitHub pull request
+// or a GitLab merge request.
+type Change struct {
+	ID       string `json:"id"`
+	ChangeID string `json:"change_id"`
+	Number   int64  `json:"_number"`
+	Project  string `json:"project"`
+	Branch   string `json:"branch"`
+	Status   string `json:"status"`
+	Created  string `json:"created"`
+	Updated  string `json:"updated"`
+}
+
+// CreatedAt parses the Created field using Gerrit's timestamp layout.
+func (c *Change) CreatedAt() (time.Time, error) {
+	return ParseTime(c.Created)
+}
+
+// Approval is a single Code-Review (or other label) vote on a change,
+// carried by comment-added stream events.
+type Approval struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+}
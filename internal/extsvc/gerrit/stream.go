@@ -0,0 +1,157 @@
+package gerrit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// StreamEvent is a single line decoded from `gerrit stream-events`. Only the
+// fields common to the event kinds we care about are parsed eagerly; the
+// rest of the payload is kept around in Raw so callers can decode the
+// event-specific fields themselves.
+type StreamEvent struct {
+	Type      string          `json:"type"`
+	EventTime string          `json:"eventCreatedOn,omitempty"`
+	Change    *Change         `json:"change,omitempty"`
+	Approvals []Approval      `json:"approvals,omitempty"`
+	Raw       json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes data into e's fields and additionally stashes a copy
+// of data itself in e.Raw, since encoding/json never populates a field
+// tagged json:"-".
+func (e *StreamEvent) UnmarshalJSON(data []byte) error {
+	type streamEvent StreamEvent
+
+	var a streamEvent
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+
+	*e = StreamEvent(a)
+	e.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Timestamp parses EventTime using Gerrit's timestamp layout. It returns the
+// zero time if EventTime is empty or malformed.
+func (e StreamEvent) Timestamp() time.Time {
+	t, err := ParseTime(e.EventTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Recognized values of StreamEvent.Type.
+const (
+	StreamEventPatchsetCreated = "patchset-created"
+	StreamEventChangeMerged    = "change-merged"
+	StreamEventChangeAbandoned = "change-abandoned"
+	StreamEventChangeRestored  = "change-restored"
+	StreamEventCommentAdded    = "comment-added"
+)
+
+// StreamWatcher connects to a Gerrit instance over SSH and decodes its
+// stream-events feed, reconnecting with a backoff whenever the connection is
+// dropped.
+type StreamWatcher struct {
+	// Host is passed to the ssh command as the destination, e.g.
+	// "gerrit.example.com" or "user@gerrit.example.com".
+	Host string
+	// Port is the Gerrit SSH port, typically 29418.
+	Port int
+
+	// MinBackoff and MaxBackoff bound the reconnect delay. They default to
+	// 1s and 30s respectively when zero.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Watch runs `ssh <host> -p <port> gerrit stream-events`, decoding each
+// line of output as a StreamEvent and sending it on the returned channel
+// until ctx is canceled. On EOF or any other error it reconnects after an
+// exponential backoff, so callers can treat the channel as a
+// never-ending stream of events for the lifetime of ctx.
+func (w *StreamWatcher) Watch(ctx context.Context) <-chan StreamEvent {
+	events := make(chan StreamEvent)
+
+	go func() {
+		defer close(events)
+
+		backoff := w.MinBackoff
+		if backoff == 0 {
+			backoff = time.Second
+		}
+		maxBackoff := w.MaxBackoff
+		if maxBackoff == 0 {
+			maxBackoff = 30 * time.Second
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err := w.stream(ctx, events); err != nil {
+				log.Printf("gerrit: stream-events connection to %s lost: %s, reconnecting in %s", w.Host, err, backoff)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+
+	return events
+}
+
+// stream opens a single ssh connection and decodes events from it until the
+// connection is closed (EOF) or ctx is canceled.
+func (w *StreamWatcher) stream(ctx context.Context, events chan<- StreamEvent) error {
+	port := w.Port
+	if port == 0 {
+		port = 29418
+	}
+	cmd := exec.CommandContext(ctx, "ssh", w.Host, "-p", strconv.Itoa(port), "gerrit", "stream-events")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer cmd.Wait()
+
+	dec := json.NewDecoder(bufio.NewReader(stdout))
+	for {
+		var ev StreamEvent
+		if err := dec.Decode(&ev); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestStreamEventUnmarshalJSON(t *testing.T) {
+	body := `{"type":"change-merged","eventCreatedOn":"1136239445","change":{"_number":1}}`
+
+	var ev StreamEvent
+	if err := json.Unmarshal([]byte(body), &ev); err != nil {
+		t.Fatal(err)
+	}
+
+	if ev.Type != "change-merged" {
+		t.Errorf("have Type %q, want %q", ev.Type, "change-merged")
+	}
+	if ev.Change == nil || ev.Change.Number != 1 {
+		t.Errorf("have Change %+v, want Number 1", ev.Change)
+	}
+	if !bytes.Equal(ev.Raw, []byte(body)) {
+		t.Errorf("have Raw %s, want %s", ev.Raw, body)
+	}
+}
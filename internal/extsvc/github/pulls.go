@@ -0,0 +1,37 @@
+package github
+
+import "time"
+
+// PullRequest is a GitHub pull request.
+type PullRequest struct {
+	ID        int64     `json:"id"`
+	Number    int64     `json:"number"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Actor identifies the GitHub user who authored an event, e.g. a review.
+type Actor struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// PullRequestReview is a review submitted on a pull request. Its State is
+// one of "APPROVED", "CHANGES_REQUESTED", "PENDING", "COMMENTED" or
+// "DISMISSED", matching the values GitHub's API uses.
+type PullRequestReview struct {
+	ID        int64     `json:"id"`
+	State     string    `json:"state"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"submitted_at"`
+	Author    Actor     `json:"user"`
+}
+
+// ReviewRequestRemoved is the metadata for a pending review request that was
+// withdrawn from a reviewer before they responded to it. The reviewer may
+// have submitted an earlier review before that request was made.
+type ReviewRequestRemoved struct {
+	Reviewer  Actor     `json:"requested_reviewer"`
+	RemovedAt time.Time `json:"-"`
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 type Webhook struct {
@@ -20,6 +22,19 @@ type WebhookConfig struct {
 	Secret      string `json:"secret"`
 }
 
+// WebhookDelivery is a single attempt GitHub made to deliver a webhook event.
+type WebhookDelivery struct {
+	ID          int64     `json:"id"`
+	GUID        string    `json:"guid"`
+	DeliveredAt time.Time `json:"delivered_at"`
+	Redelivery  bool      `json:"redelivery"`
+	Duration    float64   `json:"duration"`
+	Status      string    `json:"status"`
+	StatusCode  int       `json:"status_code"`
+	Event       string    `json:"event"`
+	Action      string    `json:"action"`
+}
+
 func (c *Client) ListOrgWebhooks(ctx context.Context, org string) (ws []Webhook, _ error) {
 	return ws, c.requestGet(ctx, "", "orgs/"+org+"/hooks", &ws)
 }
@@ -39,3 +54,80 @@ func (c *Client) CreateOrgWebhook(ctx context.Context, org string, w *Webhook) e
 
 	return c.do(ctx, "", req, w)
 }
+
+// ListRepoWebhooks lists the webhooks configured on owner/repo.
+func (c *Client) ListRepoWebhooks(ctx context.Context, owner, repo string) (ws []Webhook, _ error) {
+	return ws, c.requestGet(ctx, "", fmt.Sprintf("repos/%s/%s/hooks", owner, repo), &ws)
+}
+
+// CreateRepoWebhook creates a webhook on owner/repo.
+func (c *Client) CreateRepoWebhook(ctx context.Context, owner, repo string, w *Webhook) error {
+	w.Name = "web"
+
+	body, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("repos/%s/%s/hooks", owner, repo), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, w)
+}
+
+// UpdateRepoWebhook updates the webhook identified by id on owner/repo.
+func (c *Client) UpdateRepoWebhook(ctx context.Context, owner, repo string, id int, w *Webhook) error {
+	body, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("repos/%s/%s/hooks/%d", owner, repo, id), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, w)
+}
+
+// DeleteRepoWebhook deletes the webhook identified by id on owner/repo.
+func (c *Client) DeleteRepoWebhook(ctx context.Context, owner, repo string, id int) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("repos/%s/%s/hooks/%d", owner, repo, id), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, nil)
+}
+
+// PingWebhook asks GitHub to send a ping event to the webhook identified by
+// id on owner/repo, so operators can verify the endpoint is reachable.
+func (c *Client) PingWebhook(ctx context.Context, owner, repo string, id int) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("repos/%s/%s/hooks/%d/pings", owner, repo, id), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, nil)
+}
+
+// ListWebhookDeliveries lists the most recent deliveries GitHub made to the
+// webhook identified by id on owner/repo.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, owner, repo string, id int) (ds []WebhookDelivery, _ error) {
+	return ds, c.requestGet(ctx, "", fmt.Sprintf("repos/%s/%s/hooks/%d/deliveries", owner, repo, id), &ds)
+}
+
+// RedeliverWebhookDelivery asks GitHub to redeliver a previous delivery of
+// the webhook identified by hookID on owner/repo, so an operator can recover
+// a changeset event that was dropped during an outage without re-syncing
+// the whole repository.
+func (c *Client) RedeliverWebhookDelivery(ctx context.Context, owner, repo string, hookID int, deliveryID int64) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("repos/%s/%s/hooks/%d/deliveries/%d/attempts", owner, repo, hookID, deliveryID), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, nil)
+}
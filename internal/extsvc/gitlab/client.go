@@ -0,0 +1,73 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client talks to a GitLab instance's REST API.
+type Client struct {
+	URL        string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client configured to talk to the GitLab instance at
+// the given base URL, e.g. "https://gitlab.example.com", authenticating
+// requests with token.
+func NewClient(baseURL, token string) *Client {
+	return &Client{URL: strings.TrimSuffix(baseURL, "/"), Token: token, HTTPClient: http.DefaultClient}
+}
+
+// requestGet issues a GET request for path, relative to the API root, and
+// decodes the JSON response body into v.
+func (c *Client) requestGet(ctx context.Context, token, path string, v interface{}) error {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, token, req, v)
+}
+
+// do resolves req's URL against the API root, authenticates it with token
+// (or c.Token, if token is empty), sends it, and decodes the JSON response
+// body into v, if v is non-nil.
+func (c *Client) do(ctx context.Context, token string, req *http.Request, v interface{}) error {
+	u, err := url.Parse(c.URL + "/api/v4/" + strings.TrimPrefix(req.URL.String(), "/"))
+	if err != nil {
+		return err
+	}
+	req.URL = u
+	req = req.WithContext(ctx)
+
+	if token == "" {
+		token = c.Token
+	}
+	req.Header.Set("Private-Token", token)
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("gitlab: unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,27 @@
+// Package gitlab contains the client and types needed to talk to a GitLab
+// instance's REST API, as well as to interpret the webhook payloads and
+// system notes it sends for merge requests.
+package gitlab
+
+import "time"
+
+// MergeRequest is a GitLab merge request, the GitLab equivalent of a GitHub
+// pull request.
+type MergeRequest struct {
+	ID        int64     `json:"id"`
+	IID       int64     `json:"iid"`
+	ProjectID int64     `json:"project_id"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Note is a GitLab note (comment), including the system notes GitLab
+// generates for events such as approvals being granted or withdrawn.
+type Note struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	System    bool      `json:"system"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Webhook is a GitLab group or project webhook (GitLab calls both a
+// "hook"), as returned and accepted by the group and project hooks API
+// endpoints.
+type Webhook struct {
+	ID                    int    `json:"id,omitempty"`
+	URL                   string `json:"url"`
+	Token                 string `json:"token,omitempty"`
+	PushEvents            bool   `json:"push_events"`
+	MergeRequestsEvents   bool   `json:"merge_requests_events"`
+	NoteEvents            bool   `json:"note_events"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+}
+
+// ListGroupWebhooks lists the webhooks configured on a GitLab group.
+func (c *Client) ListGroupWebhooks(ctx context.Context, group string) (ws []Webhook, _ error) {
+	return ws, c.requestGet(ctx, "", "groups/"+group+"/hooks", &ws)
+}
+
+// CreateGroupWebhook adds a new webhook to a GitLab group.
+func (c *Client) CreateGroupWebhook(ctx context.Context, group string, w *Webhook) error {
+	body, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", "groups/"+group+"/hooks", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, w)
+}
+
+// ListProjectWebhooks lists the webhooks configured on a GitLab project.
+func (c *Client) ListProjectWebhooks(ctx context.Context, project string) (ws []Webhook, _ error) {
+	return ws, c.requestGet(ctx, "", "projects/"+project+"/hooks", &ws)
+}
+
+// CreateProjectWebhook adds a new webhook to a GitLab project.
+func (c *Client) CreateProjectWebhook(ctx context.Context, project string, w *Webhook) error {
+	body, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", "projects/"+project+"/hooks", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "", req, w)
+}